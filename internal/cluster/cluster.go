@@ -0,0 +1,20 @@
+// Package cluster wraps the various Kubernetes clients Octant needs to
+// talk to a cluster behind a single interface so the rest of the code
+// base doesn't have to juggle them individually.
+package cluster
+
+import (
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+// ClientInterface is the interface for cluster clients.
+type ClientInterface interface {
+	// DynamicClient returns a client for interacting with arbitrary
+	// Kubernetes APIs in an unstructured way.
+	DynamicClient() (dynamic.Interface, error)
+
+	// DiscoveryClient returns a client for discovering the APIs
+	// supported by the cluster.
+	DiscoveryClient() (discovery.DiscoveryInterface, error)
+}
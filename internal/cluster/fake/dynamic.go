@@ -0,0 +1,326 @@
+package fake
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	clientgotesting "k8s.io/client-go/testing"
+)
+
+// fakeDynamicClient is a fake dynamic.Interface backed by an
+// ObjectTracker, following the same shape as
+// k8s.io/client-go/dynamic/fake.FakeDynamicClient. It's vendored here
+// locally rather than used directly because that package's List() never
+// records the scheme it was built with, so every List() call fails
+// trying to convert through a nil scheme.
+type fakeDynamicClient struct {
+	clientgotesting.Fake
+	scheme *runtime.Scheme
+}
+
+func newDynamicClient(scheme *runtime.Scheme, objects ...runtime.Object) *fakeDynamicClient {
+	codecs := serializer.NewCodecFactory(scheme)
+	tracker := clientgotesting.NewObjectTracker(scheme, codecs.UniversalDecoder())
+	for _, obj := range objects {
+		if err := tracker.Add(obj); err != nil {
+			panic(err)
+		}
+	}
+
+	c := &fakeDynamicClient{scheme: scheme}
+	c.AddReactor("*", "*", clientgotesting.ObjectReaction(tracker))
+	c.AddWatchReactor("*", func(action clientgotesting.Action) (bool, watch.Interface, error) {
+		w, err := tracker.Watch(action.GetResource(), action.GetNamespace())
+		if err != nil {
+			return false, nil, err
+		}
+		return true, w, nil
+	})
+
+	return c
+}
+
+var _ dynamic.Interface = (*fakeDynamicClient)(nil)
+
+func (c *fakeDynamicClient) Resource(resource schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &fakeDynamicResource{client: c, resource: resource}
+}
+
+type fakeDynamicResource struct {
+	client    *fakeDynamicClient
+	namespace string
+	resource  schema.GroupVersionResource
+}
+
+var _ dynamic.NamespaceableResourceInterface = (*fakeDynamicResource)(nil)
+
+func (c *fakeDynamicResource) Namespace(ns string) dynamic.ResourceInterface {
+	ret := *c
+	ret.namespace = ns
+	return &ret
+}
+
+func (c *fakeDynamicResource) Create(obj *unstructured.Unstructured, subresources ...string) (*unstructured.Unstructured, error) {
+	var uncastRet runtime.Object
+	var err error
+	switch {
+	case len(c.namespace) == 0 && len(subresources) == 0:
+		uncastRet, err = c.client.Invokes(clientgotesting.NewRootCreateAction(c.resource, obj), obj)
+	case len(c.namespace) == 0 && len(subresources) > 0:
+		accessor, aerr := meta.Accessor(obj)
+		if aerr != nil {
+			return nil, aerr
+		}
+		uncastRet, err = c.client.Invokes(clientgotesting.NewRootCreateSubresourceAction(c.resource, accessor.GetName(), strings.Join(subresources, "/"), obj), obj)
+	case len(c.namespace) > 0 && len(subresources) == 0:
+		uncastRet, err = c.client.Invokes(clientgotesting.NewCreateAction(c.resource, c.namespace, obj), obj)
+	default:
+		accessor, aerr := meta.Accessor(obj)
+		if aerr != nil {
+			return nil, aerr
+		}
+		uncastRet, err = c.client.Invokes(clientgotesting.NewCreateSubresourceAction(c.resource, accessor.GetName(), strings.Join(subresources, "/"), c.namespace, obj), obj)
+	}
+
+	return c.toUnstructured(uncastRet, err)
+}
+
+func (c *fakeDynamicResource) Update(obj *unstructured.Unstructured, subresources ...string) (*unstructured.Unstructured, error) {
+	var uncastRet runtime.Object
+	var err error
+	switch {
+	case len(c.namespace) == 0 && len(subresources) == 0:
+		uncastRet, err = c.client.Invokes(clientgotesting.NewRootUpdateAction(c.resource, obj), obj)
+	case len(c.namespace) == 0 && len(subresources) > 0:
+		uncastRet, err = c.client.Invokes(clientgotesting.NewRootUpdateSubresourceAction(c.resource, strings.Join(subresources, "/"), obj), obj)
+	case len(c.namespace) > 0 && len(subresources) == 0:
+		uncastRet, err = c.client.Invokes(clientgotesting.NewUpdateAction(c.resource, c.namespace, obj), obj)
+	default:
+		uncastRet, err = c.client.Invokes(clientgotesting.NewUpdateSubresourceAction(c.resource, strings.Join(subresources, "/"), c.namespace, obj), obj)
+	}
+
+	return c.toUnstructured(uncastRet, err)
+}
+
+func (c *fakeDynamicResource) UpdateStatus(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	var uncastRet runtime.Object
+	var err error
+	if len(c.namespace) == 0 {
+		uncastRet, err = c.client.Invokes(clientgotesting.NewRootUpdateSubresourceAction(c.resource, "status", obj), obj)
+	} else {
+		uncastRet, err = c.client.Invokes(clientgotesting.NewUpdateSubresourceAction(c.resource, "status", c.namespace, obj), obj)
+	}
+
+	return c.toUnstructured(uncastRet, err)
+}
+
+func (c *fakeDynamicResource) Delete(name string, opts *metav1.DeleteOptions, subresources ...string) error {
+	var err error
+	switch {
+	case len(c.namespace) == 0 && len(subresources) == 0:
+		_, err = c.client.Invokes(clientgotesting.NewRootDeleteAction(c.resource, name), &metav1.Status{Status: "dynamic delete fail"})
+	case len(c.namespace) == 0 && len(subresources) > 0:
+		_, err = c.client.Invokes(clientgotesting.NewRootDeleteSubresourceAction(c.resource, strings.Join(subresources, "/"), name), &metav1.Status{Status: "dynamic delete fail"})
+	case len(c.namespace) > 0 && len(subresources) == 0:
+		_, err = c.client.Invokes(clientgotesting.NewDeleteAction(c.resource, c.namespace, name), &metav1.Status{Status: "dynamic delete fail"})
+	default:
+		_, err = c.client.Invokes(clientgotesting.NewDeleteSubresourceAction(c.resource, strings.Join(subresources, "/"), c.namespace, name), &metav1.Status{Status: "dynamic delete fail"})
+	}
+
+	return err
+}
+
+func (c *fakeDynamicResource) DeleteCollection(opts *metav1.DeleteOptions, listOptions metav1.ListOptions) error {
+	var err error
+	if len(c.namespace) == 0 {
+		_, err = c.client.Invokes(clientgotesting.NewRootDeleteCollectionAction(c.resource, listOptions), &metav1.Status{Status: "dynamic deletecollection fail"})
+	} else {
+		_, err = c.client.Invokes(clientgotesting.NewDeleteCollectionAction(c.resource, c.namespace, listOptions), &metav1.Status{Status: "dynamic deletecollection fail"})
+	}
+
+	return err
+}
+
+func (c *fakeDynamicResource) Get(name string, opts metav1.GetOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	var uncastRet runtime.Object
+	var err error
+	switch {
+	case len(c.namespace) == 0 && len(subresources) == 0:
+		uncastRet, err = c.client.Invokes(clientgotesting.NewRootGetAction(c.resource, name), &metav1.Status{Status: "dynamic get fail"})
+	case len(c.namespace) == 0 && len(subresources) > 0:
+		uncastRet, err = c.client.Invokes(clientgotesting.NewRootGetSubresourceAction(c.resource, strings.Join(subresources, "/"), name), &metav1.Status{Status: "dynamic get fail"})
+	case len(c.namespace) > 0 && len(subresources) == 0:
+		uncastRet, err = c.client.Invokes(clientgotesting.NewGetAction(c.resource, c.namespace, name), &metav1.Status{Status: "dynamic get fail"})
+	default:
+		uncastRet, err = c.client.Invokes(clientgotesting.NewGetSubresourceAction(c.resource, c.namespace, strings.Join(subresources, "/"), name), &metav1.Status{Status: "dynamic get fail"})
+	}
+
+	return c.toUnstructured(uncastRet, err)
+}
+
+func (c *fakeDynamicResource) List(opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	var obj runtime.Object
+	var err error
+	if len(c.namespace) == 0 {
+		obj, err = c.client.Invokes(clientgotesting.NewRootListAction(c.resource, schema.GroupVersionKind{Version: "v1", Kind: "List"}, opts), &metav1.Status{Status: "dynamic list fail"})
+	} else {
+		obj, err = c.client.Invokes(clientgotesting.NewListAction(c.resource, schema.GroupVersionKind{Version: "v1", Kind: "List"}, c.namespace, opts), &metav1.Status{Status: "dynamic list fail"})
+	}
+	if obj == nil {
+		return nil, err
+	}
+
+	label, field, _ := clientgotesting.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	if field == nil {
+		field = fields.Everything()
+	}
+
+	retUnstructured := &unstructured.Unstructured{}
+	if err := c.client.scheme.Convert(obj, retUnstructured, nil); err != nil {
+		return nil, err
+	}
+	entireList, err := retUnstructured.ToList()
+	if err != nil {
+		return nil, err
+	}
+
+	list := &unstructured.UnstructuredList{}
+	for _, item := range entireList.Items {
+		metadata, err := meta.Accessor(&item)
+		if err != nil {
+			return nil, err
+		}
+		if !label.Matches(labels.Set(metadata.GetLabels())) {
+			continue
+		}
+		if !field.Matches(fields.Set{"metadata.name": metadata.GetName(), "metadata.namespace": metadata.GetNamespace()}) {
+			continue
+		}
+		list.Items = append(list.Items, item)
+	}
+	return list, nil
+}
+
+// Watch behaves like a real apiserver watch in that it only delivers
+// events for objects matching opts' label selector; ObjectTracker.Watch
+// itself has no notion of selectors, so non-matching events are
+// filtered out of the underlying watch stream here instead.
+func (c *fakeDynamicResource) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	var (
+		w   watch.Interface
+		err error
+	)
+	if len(c.namespace) == 0 {
+		w, err = c.client.InvokesWatch(clientgotesting.NewRootWatchAction(c.resource, opts))
+	} else {
+		w, err = c.client.InvokesWatch(clientgotesting.NewWatchAction(c.resource, c.namespace, opts))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	label, _, _ := clientgotesting.ExtractFromListOptions(opts)
+	if label == nil || label.Empty() {
+		return w, nil
+	}
+	return newLabelFilteredWatch(w, label), nil
+}
+
+func (c *fakeDynamicResource) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (*unstructured.Unstructured, error) {
+	var uncastRet runtime.Object
+	var err error
+	switch {
+	case len(c.namespace) == 0 && len(subresources) == 0:
+		uncastRet, err = c.client.Invokes(clientgotesting.NewRootPatchAction(c.resource, name, data), &metav1.Status{Status: "dynamic patch fail"})
+	case len(c.namespace) == 0 && len(subresources) > 0:
+		uncastRet, err = c.client.Invokes(clientgotesting.NewRootPatchSubresourceAction(c.resource, name, data, subresources...), &metav1.Status{Status: "dynamic patch fail"})
+	case len(c.namespace) > 0 && len(subresources) == 0:
+		uncastRet, err = c.client.Invokes(clientgotesting.NewPatchAction(c.resource, c.namespace, name, data), &metav1.Status{Status: "dynamic patch fail"})
+	default:
+		uncastRet, err = c.client.Invokes(clientgotesting.NewPatchSubresourceAction(c.resource, c.namespace, name, data, subresources...), &metav1.Status{Status: "dynamic patch fail"})
+	}
+
+	return c.toUnstructured(uncastRet, err)
+}
+
+func (c *fakeDynamicResource) toUnstructured(uncastRet runtime.Object, err error) (*unstructured.Unstructured, error) {
+	if err != nil {
+		return nil, err
+	}
+	if uncastRet == nil {
+		return nil, nil
+	}
+
+	ret := &unstructured.Unstructured{}
+	if err := c.client.scheme.Convert(uncastRet, ret, nil); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// labelFilteredWatch wraps a watch.Interface, forwarding only events for
+// objects matching label.
+type labelFilteredWatch struct {
+	source watch.Interface
+	label  labels.Selector
+	result chan watch.Event
+	stopCh chan struct{}
+}
+
+func newLabelFilteredWatch(source watch.Interface, label labels.Selector) watch.Interface {
+	w := &labelFilteredWatch{
+		source: source,
+		label:  label,
+		result: make(chan watch.Event),
+		stopCh: make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *labelFilteredWatch) run() {
+	defer close(w.result)
+
+	for {
+		select {
+		case event, ok := <-w.source.ResultChan():
+			if !ok {
+				return
+			}
+
+			if u, ok := event.Object.(*unstructured.Unstructured); ok && !w.label.Matches(labels.Set(u.GetLabels())) {
+				continue
+			}
+
+			select {
+			case w.result <- event:
+			case <-w.stopCh:
+				return
+			}
+		case <-w.stopCh:
+			return
+		}
+	}
+}
+
+func (w *labelFilteredWatch) Stop() {
+	close(w.stopCh)
+	w.source.Stop()
+}
+
+func (w *labelFilteredWatch) ResultChan() <-chan watch.Event {
+	return w.result
+}
@@ -0,0 +1,66 @@
+// Package fake provides a cluster.ClientInterface backed entirely by the
+// fake clients from client-go, for use in tests.
+package fake
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	discoveryfake "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic"
+	clientgotesting "k8s.io/client-go/testing"
+
+	"github.com/heptio/developer-dash/internal/cluster"
+)
+
+// Client is a cluster.ClientInterface implementation backed by fake
+// Kubernetes clients. Tests can reach into FakeDynamic/FakeDiscovery to
+// set up resources and assert on actions taken against them.
+type Client struct {
+	FakeDynamic   dynamic.Interface
+	FakeDiscovery *discoveryfake.FakeDiscovery
+}
+
+var _ cluster.ClientInterface = (*Client)(nil)
+
+// NewClient creates a fake Client seeded with the given objects.
+func NewClient(scheme *runtime.Scheme, objects []runtime.Object) (*Client, error) {
+	// The fake dynamic client's List always asks the tracker for a
+	// "List"-kind object and lets the tracker append the "List" suffix
+	// itself, so it needs a generic v1/ListList type registered in
+	// addition to whatever concrete *List kinds the caller's scheme has.
+	scheme.AddKnownTypeWithName(schema.GroupVersionKind{Version: "v1", Kind: "ListList"}, &unstructured.UnstructuredList{})
+
+	dynamicClient := newDynamicClient(scheme, objects...)
+
+	fakePtr := clientgotesting.Fake{}
+	fakeDiscovery := &discoveryfake.FakeDiscovery{Fake: &fakePtr}
+
+	return &Client{
+		FakeDynamic:   dynamicClient,
+		FakeDiscovery: fakeDiscovery,
+	}, nil
+}
+
+// DynamicClient returns the fake dynamic client.
+func (c *Client) DynamicClient() (dynamic.Interface, error) {
+	return c.FakeDynamic, nil
+}
+
+// DiscoveryClient returns the fake discovery client.
+func (c *Client) DiscoveryClient() (discovery.DiscoveryInterface, error) {
+	return c.FakeDiscovery, nil
+}
+
+// BlockList makes every List call against resource block until unblock
+// is closed, then falls through to the normal fake list handling. It
+// lets tests simulate a list that never returns - for example a CRD
+// that's deleted mid-list - without actually hanging forever.
+func (c *Client) BlockList(resource schema.GroupVersionResource, unblock <-chan struct{}) {
+	dynamicClient := c.FakeDynamic.(*fakeDynamicClient)
+	dynamicClient.PrependReactor("list", resource.Resource, func(clientgotesting.Action) (bool, runtime.Object, error) {
+		<-unblock
+		return false, nil, nil
+	})
+}
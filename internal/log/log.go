@@ -0,0 +1,83 @@
+// Package log provides a small structured logging interface so callers
+// don't have to depend directly on a particular logging library.
+package log
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Logger is the logging interface used throughout this codebase.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+	With(args ...interface{}) Logger
+	WithErr(err error) Logger
+}
+
+type logrusLogger struct {
+	entry *logrus.Entry
+}
+
+// NewLogger creates a Logger backed by logrus.
+func NewLogger(entry *logrus.Entry) Logger {
+	return &logrusLogger{entry: entry}
+}
+
+func (l *logrusLogger) Debugf(format string, args ...interface{}) {
+	l.entry.Debugf(format, args...)
+}
+
+func (l *logrusLogger) Infof(format string, args ...interface{}) {
+	l.entry.Infof(format, args...)
+}
+
+func (l *logrusLogger) Warnf(format string, args ...interface{}) {
+	l.entry.Warnf(format, args...)
+}
+
+func (l *logrusLogger) Errorf(format string, args ...interface{}) {
+	l.entry.Errorf(format, args...)
+}
+
+func (l *logrusLogger) With(args ...interface{}) Logger {
+	return &logrusLogger{entry: l.entry.WithFields(fieldsFromArgs(args...))}
+}
+
+func (l *logrusLogger) WithErr(err error) Logger {
+	return &logrusLogger{entry: l.entry.WithError(err)}
+}
+
+func fieldsFromArgs(args ...interface{}) logrus.Fields {
+	fields := logrus.Fields{}
+	for i := 0; i+1 < len(args); i += 2 {
+		key, ok := args[i].(string)
+		if !ok {
+			continue
+		}
+		fields[key] = args[i+1]
+	}
+	return fields
+}
+
+// testWriter adapts a *testing.T into an io.Writer so test output is
+// interleaved correctly with `go test` output.
+type testWriter struct {
+	t *testing.T
+}
+
+func (w *testWriter) Write(p []byte) (int, error) {
+	w.t.Logf("%s", p)
+	return len(p), nil
+}
+
+// TestLogger returns a Logger that writes to the given test's log output.
+func TestLogger(t *testing.T) Logger {
+	logger := logrus.New()
+	logger.Out = &testWriter{t: t}
+	logger.Level = logrus.DebugLevel
+	return NewLogger(logrus.NewEntry(logger))
+}
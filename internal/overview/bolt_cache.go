@@ -0,0 +1,259 @@
+package overview
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/heptio/developer-dash/internal/log"
+)
+
+// boltSchemaVersion is prefixed onto every value BoltCache writes, so a
+// later Octant version can tell which encoding it's reading and migrate
+// old values instead of failing to parse them.
+const boltSchemaVersion byte = 1
+
+// defaultWriteBehindBuffer bounds how many pending writes BoltCache will
+// queue before Store/Delete start blocking the caller - normally the
+// informer goroutine delivering watch events - on a slow disk fsync.
+const defaultWriteBehindBuffer = 256
+
+var boltBucket = []byte("objects")
+
+// boltWrite is a pending mutation for the write-behind goroutine to
+// apply; obj is nil for a delete.
+type boltWrite struct {
+	key       CacheKey
+	namespace string
+	obj       *unstructured.Unstructured
+}
+
+// BoltCache is a disk-backed Cache implementation keyed by
+// namespace/apiVersion/kind/name, so Octant doesn't have to re-list an
+// entire cluster after a restart and large clusters don't have to fit
+// in memory. Writes go through a bounded write-behind buffer: Store and
+// Delete hand their mutation to a background goroutine and return
+// without waiting for it to be applied, so a burst of up to
+// defaultWriteBehindBuffer watch events doesn't stall the informer
+// goroutine on an fsync. Once the buffer is full, Store and Delete
+// block until the background goroutine frees a slot.
+type BoltCache struct {
+	db     *bolt.DB
+	logger log.Logger
+
+	writeCh chan boltWrite
+	closeCh chan struct{}
+	doneCh  chan struct{}
+
+	notifier
+}
+
+var _ Cache = (*BoltCache)(nil)
+
+// NewBoltCache creates a BoltCache backed by the BoltDB file at path,
+// creating it if it doesn't already exist.
+func NewBoltCache(path string, logger log.Logger, opts ...CacheOpt) (*BoltCache, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open bolt cache %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create bolt cache bucket: %w", err)
+	}
+
+	c := &BoltCache{
+		db:      db,
+		logger:  logger,
+		writeCh: make(chan boltWrite, defaultWriteBehindBuffer),
+		closeCh: make(chan struct{}),
+		doneCh:  make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(&c.notifier)
+	}
+
+	go c.writeBehind()
+
+	return c, nil
+}
+
+// Close stops the write-behind goroutine, waits for any buffered writes
+// to be applied, and closes the underlying BoltDB file.
+func (c *BoltCache) Close() error {
+	close(c.closeCh)
+	<-c.doneCh
+	return c.db.Close()
+}
+
+// Store queues obj to be written to namespace's slice of the cache and
+// returns once it is queued, blocking only while the write-behind buffer
+// is full; the write itself, and the resulting
+// CacheNotification, happen on the write-behind goroutine.
+func (c *BoltCache) Store(namespace string, obj *unstructured.Unstructured) error {
+	c.writeCh <- boltWrite{key: keyForObject(namespace, obj), namespace: namespace, obj: obj}
+	return nil
+}
+
+// Delete queues obj to be removed from namespace's slice of the cache
+// and returns once it is queued, blocking only while the write-behind
+// buffer is full; the delete itself, and the resulting
+// CacheNotification, happen on the write-behind goroutine.
+func (c *BoltCache) Delete(namespace string, obj *unstructured.Unstructured) error {
+	c.writeCh <- boltWrite{key: keyForObject(namespace, obj), namespace: namespace, obj: nil}
+	return nil
+}
+
+// Retrieve returns every object in the cache matching key, decoded from
+// disk. A zero-value field in key matches any value for that field, and
+// key.LabelSelector/key.FieldSelector, if set, are evaluated the same
+// way MemoryCache.Retrieve evaluates them.
+func (c *BoltCache) Retrieve(key CacheKey) ([]*unstructured.Unstructured, error) {
+	var out []*unstructured.Unstructured
+
+	err := c.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).ForEach(func(k, v []byte) error {
+			candidate, err := decodeBoltKey(k)
+			if err != nil {
+				return err
+			}
+			if !matches(key, candidate) {
+				return nil
+			}
+
+			obj, err := decodeObject(v)
+			if err != nil {
+				return err
+			}
+
+			if key.LabelSelector != nil && !key.LabelSelector.Matches(labels.Set(obj.GetLabels())) {
+				return nil
+			}
+			if key.FieldSelector != nil && !key.FieldSelector.Matches(objectFields(obj)) {
+				return nil
+			}
+
+			out = append(out, obj)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// writeBehind applies buffered writes until closeCh is closed, then
+// drains whatever's left in writeCh before exiting, so Close never loses
+// a write that was already accepted by Store/Delete.
+func (c *BoltCache) writeBehind() {
+	defer close(c.doneCh)
+
+	for {
+		select {
+		case w := <-c.writeCh:
+			c.applyWrite(w)
+		case <-c.closeCh:
+			for {
+				select {
+				case w := <-c.writeCh:
+					c.applyWrite(w)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (c *BoltCache) applyWrite(w boltWrite) {
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltBucket)
+		k := boltKey(w.key)
+
+		if w.obj == nil {
+			return b.Delete(k)
+		}
+
+		v, err := encodeObject(w.obj)
+		if err != nil {
+			return err
+		}
+		return b.Put(k, v)
+	})
+	if err != nil {
+		c.logger.WithErr(err).Errorf("write %s to bolt cache", w.key.Name)
+		return
+	}
+
+	c.Notify(w.key, w.namespace)
+}
+
+// boltKeySep separates the namespace/apiVersion/kind/name segments of a
+// bolt key. apiVersion ("apps/v1") already uses '/', so that can't be
+// the separator; the unit separator control character never appears in
+// any of the four fields.
+const boltKeySep = "\x1f"
+
+// boltKey renders key as the BoltDB key its object is stored under.
+// CacheKey.LabelSelector/FieldSelector are query-only and never part of
+// this encoding.
+func boltKey(key CacheKey) []byte {
+	return []byte(strings.Join([]string{key.Namespace, key.APIVersion, key.Kind, key.Name}, boltKeySep))
+}
+
+// decodeBoltKey is boltKey's inverse, used by Retrieve to test a stored
+// key against a query CacheKey without decoding the (possibly large)
+// value alongside it.
+func decodeBoltKey(k []byte) (CacheKey, error) {
+	parts := strings.Split(string(k), boltKeySep)
+	if len(parts) != 4 {
+		return CacheKey{}, fmt.Errorf("decode bolt cache key %q: expected 4 parts, got %d", k, len(parts))
+	}
+
+	return CacheKey{Namespace: parts[0], APIVersion: parts[1], Kind: parts[2], Name: parts[3]}, nil
+}
+
+// encodeObject serializes obj as schema-versioned JSON, so a later
+// Octant version can evolve the on-disk encoding and still recognize
+// values a previous version wrote.
+func encodeObject(obj *unstructured.Unstructured) ([]byte, error) {
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{boltSchemaVersion}, data...), nil
+}
+
+// decodeObject is encodeObject's inverse.
+func decodeObject(data []byte) (*unstructured.Unstructured, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("decode bolt cache value: empty")
+	}
+
+	version, data := data[0], data[1:]
+	if version != boltSchemaVersion {
+		return nil, fmt.Errorf("decode bolt cache value: unsupported schema version %d", version)
+	}
+
+	var content map[string]interface{}
+	if err := json.Unmarshal(data, &content); err != nil {
+		return nil, err
+	}
+
+	return &unstructured.Unstructured{Object: content}, nil
+}
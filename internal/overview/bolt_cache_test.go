@@ -0,0 +1,118 @@
+package overview
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/heptio/developer-dash/internal/log"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestBoltCache(t *testing.T) {
+	notifyCh := make(chan CacheNotification, 1)
+	notifyDone := make(chan struct{})
+	defer close(notifyDone)
+
+	path := filepath.Join(t.TempDir(), "cache.db")
+
+	cache, err := NewBoltCache(path, log.TestLogger(t), CacheNotificationOpt(notifyCh, notifyDone))
+	require.NoError(t, err)
+	defer cache.Close()
+
+	obj := newUnstructured("apps/v1", "Deployment", "default", "deploy1")
+
+	require.NoError(t, cache.Store("default", obj))
+
+	select {
+	case n := <-notifyCh:
+		require.Equal(t, "deploy1", n.Key.Name)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for store to notify")
+	}
+
+	found, err := cache.Retrieve(CacheKey{Namespace: "default"})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	require.Equal(t, "deploy1", found[0].GetName())
+
+	require.NoError(t, cache.Delete("default", obj))
+
+	select {
+	case <-notifyCh:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for delete to notify")
+	}
+
+	found, err = cache.Retrieve(CacheKey{Namespace: "default"})
+	require.NoError(t, err)
+	require.Len(t, found, 0)
+}
+
+func TestBoltCache_RetrieveWithSelectors(t *testing.T) {
+	notifyCh := make(chan CacheNotification, 2)
+	notifyDone := make(chan struct{})
+	defer close(notifyDone)
+
+	path := filepath.Join(t.TempDir(), "cache.db")
+
+	cache, err := NewBoltCache(path, log.TestLogger(t), CacheNotificationOpt(notifyCh, notifyDone))
+	require.NoError(t, err)
+	defer cache.Close()
+
+	matching := newUnstructured("apps/v1", "Deployment", "default", "deploy-match")
+	matching.SetLabels(map[string]string{"app": "wanted"})
+
+	other := newUnstructured("apps/v1", "Deployment", "default", "deploy-other")
+	other.SetLabels(map[string]string{"app": "unwanted"})
+
+	require.NoError(t, cache.Store("default", matching))
+	require.NoError(t, cache.Store("default", other))
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-notifyCh:
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for store to notify")
+		}
+	}
+
+	found, err := cache.Retrieve(CacheKey{
+		Namespace:     "default",
+		LabelSelector: labels.SelectorFromSet(labels.Set{"app": "wanted"}),
+	})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	require.Equal(t, "deploy-match", found[0].GetName())
+
+	found, err = cache.Retrieve(CacheKey{
+		Namespace:     "default",
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", "deploy-other"),
+	})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	require.Equal(t, "deploy-other", found[0].GetName())
+}
+
+func TestBoltCache_PersistsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+
+	cache, err := NewBoltCache(path, log.TestLogger(t))
+	require.NoError(t, err)
+
+	require.NoError(t, cache.Store("default", newUnstructured("apps/v1", "Deployment", "default", "deploy1")))
+
+	// Close waits for the write-behind buffer to drain before returning.
+	require.NoError(t, cache.Close())
+
+	reopened, err := NewBoltCache(path, log.TestLogger(t))
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	found, err := reopened.Retrieve(CacheKey{Namespace: "default"})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	require.Equal(t, "deploy1", found[0].GetName())
+}
@@ -0,0 +1,195 @@
+package overview
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// CacheKey identifies a slice of the cache to store or retrieve objects
+// from. LabelSelector and FieldSelector are query-only: they're
+// evaluated against candidate objects by Retrieve, and are never set by
+// the cache itself when deriving the key an object is stored under, so
+// they play no part in object identity.
+type CacheKey struct {
+	Namespace  string
+	APIVersion string
+	Kind       string
+	Name       string
+
+	LabelSelector labels.Selector
+	FieldSelector fields.Selector
+}
+
+// CacheNotification is sent whenever the cache is updated as a result of
+// a watch event.
+type CacheNotification struct {
+	Key       CacheKey
+	Namespace string
+}
+
+// Cache is implemented by every object-store backend Watch can keep in
+// sync. MemoryCache is the default, in-memory implementation; BoltCache
+// is a disk-backed alternative for clusters too large to comfortably
+// hold in memory, or for persisting the cache across Octant restarts.
+type Cache interface {
+	Store(namespace string, obj *unstructured.Unstructured) error
+	Retrieve(key CacheKey) ([]*unstructured.Unstructured, error)
+	Delete(namespace string, obj *unstructured.Unstructured) error
+	Notify(key CacheKey, namespace string)
+}
+
+// CacheOpt is a functional option for configuring a Cache implementation.
+type CacheOpt func(*notifier)
+
+// CacheNotificationOpt configures a Cache to publish a CacheNotification
+// on notifyCh whenever the cache changes. Sends are abandoned once
+// notifyDone is closed, so callers that stop draining notifyCh can
+// unblock the cache by closing notifyDone.
+func CacheNotificationOpt(notifyCh chan CacheNotification, notifyDone chan struct{}) CacheOpt {
+	return func(n *notifier) {
+		n.notifyCh = notifyCh
+		n.notifyDone = notifyDone
+	}
+}
+
+// notifier implements the Notify half of the Cache interface, shared by
+// every backend so CacheNotificationOpt semantics stay identical
+// regardless of which one a caller picks.
+type notifier struct {
+	notifyCh   chan CacheNotification
+	notifyDone chan struct{}
+}
+
+// Notify publishes a CacheNotification if a listener was configured via
+// CacheNotificationOpt. The send is abandoned if notifyDone is closed so
+// that a caller tearing down a watch can never deadlock on a cache
+// notification nobody is listening for any more.
+func (n *notifier) Notify(key CacheKey, namespace string) {
+	if n.notifyCh == nil {
+		return
+	}
+
+	select {
+	case n.notifyCh <- CacheNotification{Key: key, Namespace: namespace}:
+	case <-n.notifyDone:
+	}
+}
+
+// MemoryCache is an in-memory store of cluster objects, populated by
+// Watch.
+type MemoryCache struct {
+	mu      sync.RWMutex
+	objects map[CacheKey]*unstructured.Unstructured
+
+	notifier
+}
+
+var _ Cache = (*MemoryCache)(nil)
+
+// NewMemoryCache creates a MemoryCache.
+func NewMemoryCache(opts ...CacheOpt) *MemoryCache {
+	c := &MemoryCache{
+		objects: make(map[CacheKey]*unstructured.Unstructured),
+	}
+
+	for _, opt := range opts {
+		opt(&c.notifier)
+	}
+
+	return c
+}
+
+func keyForObject(namespace string, obj *unstructured.Unstructured) CacheKey {
+	gvk := obj.GroupVersionKind()
+	return CacheKey{
+		Namespace:  namespace,
+		APIVersion: gvk.GroupVersion().String(),
+		Kind:       gvk.Kind,
+		Name:       obj.GetName(),
+	}
+}
+
+// Store saves obj in the cache under namespace, and notifies any
+// registered listener.
+func (c *MemoryCache) Store(namespace string, obj *unstructured.Unstructured) error {
+	key := keyForObject(namespace, obj)
+
+	c.mu.Lock()
+	c.objects[key] = obj
+	c.mu.Unlock()
+
+	c.Notify(key, namespace)
+
+	return nil
+}
+
+// Delete removes obj from the cache, and notifies any registered
+// listener.
+func (c *MemoryCache) Delete(namespace string, obj *unstructured.Unstructured) error {
+	key := keyForObject(namespace, obj)
+
+	c.mu.Lock()
+	delete(c.objects, key)
+	c.mu.Unlock()
+
+	c.Notify(key, namespace)
+
+	return nil
+}
+
+// Retrieve returns every object in the cache matching key. A zero-value
+// field in key matches any value for that field. If key.LabelSelector
+// or key.FieldSelector is set, only objects matching it are returned,
+// regardless of whether Watch was restricted to the same selector: this
+// lets a caller narrow results further than what was watched.
+func (c *MemoryCache) Retrieve(key CacheKey) ([]*unstructured.Unstructured, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var out []*unstructured.Unstructured
+	for k, obj := range c.objects {
+		if !matches(key, k) {
+			continue
+		}
+		if key.LabelSelector != nil && !key.LabelSelector.Matches(labels.Set(obj.GetLabels())) {
+			continue
+		}
+		if key.FieldSelector != nil && !key.FieldSelector.Matches(objectFields(obj)) {
+			continue
+		}
+		out = append(out, obj)
+	}
+
+	return out, nil
+}
+
+// objectFields returns the fields of obj that FieldSelector queries can
+// match against. Real field selectors are type-specific and usually
+// limited to a handful of well-known fields; metadata.name and
+// metadata.namespace are the only ones every kind has, so they're all
+// this generic, unstructured cache can offer.
+func objectFields(obj *unstructured.Unstructured) fields.Set {
+	return fields.Set{
+		"metadata.name":      obj.GetName(),
+		"metadata.namespace": obj.GetNamespace(),
+	}
+}
+
+func matches(query, candidate CacheKey) bool {
+	if query.Namespace != "" && query.Namespace != candidate.Namespace {
+		return false
+	}
+	if query.APIVersion != "" && query.APIVersion != candidate.APIVersion {
+		return false
+	}
+	if query.Kind != "" && query.Kind != candidate.Kind {
+		return false
+	}
+	if query.Name != "" && query.Name != candidate.Name {
+		return false
+	}
+	return true
+}
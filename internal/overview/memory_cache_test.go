@@ -0,0 +1,42 @@
+package overview
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestMemoryCache_RetrieveWithSelectors(t *testing.T) {
+	cache := NewMemoryCache()
+
+	matching := newUnstructured("apps/v1", "Deployment", "default", "deploy-match")
+	matching.SetLabels(map[string]string{"app": "wanted"})
+
+	other := newUnstructured("apps/v1", "Deployment", "default", "deploy-other")
+	other.SetLabels(map[string]string{"app": "unwanted"})
+
+	require.NoError(t, cache.Store("default", matching))
+	require.NoError(t, cache.Store("default", other))
+
+	found, err := cache.Retrieve(CacheKey{Namespace: "default"})
+	require.NoError(t, err)
+	require.Len(t, found, 2, "selector-less Retrieve should return everything in the namespace")
+
+	found, err = cache.Retrieve(CacheKey{
+		Namespace:     "default",
+		LabelSelector: labels.SelectorFromSet(labels.Set{"app": "wanted"}),
+	})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	require.Equal(t, "deploy-match", found[0].GetName())
+
+	found, err = cache.Retrieve(CacheKey{
+		Namespace:     "default",
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", "deploy-other"),
+	})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	require.Equal(t, "deploy-other", found[0].GetName())
+}
@@ -0,0 +1,527 @@
+package overview
+
+import (
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubewatch "k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	k8scache "k8s.io/client-go/tools/cache"
+
+	"github.com/heptio/developer-dash/internal/cluster"
+	"github.com/heptio/developer-dash/internal/log"
+)
+
+// defaultResyncInterval is how often Watch re-runs discovery to pick up
+// GVRs that have appeared, disappeared, or need their watch restarted.
+const defaultResyncInterval = 10 * time.Second
+
+// defaultStopGracePeriod is how long stopAll waits for a watcher to exit
+// before giving up on it. client-go@v8's Reflector.ListAndWatch has no
+// way to cancel a List call already in flight, so a watcher whose GVR
+// was deleted out from under it mid-list can block its informer's Run
+// forever; stopAll abandons watchers that don't exit within this window
+// instead of hanging stopFn on them.
+const defaultStopGracePeriod = 5 * time.Second
+
+// WatchOpt is a functional option for configuring a Watch.
+type WatchOpt func(*Watch)
+
+// ResyncIntervalOpt overrides how often Watch re-runs discovery.
+func ResyncIntervalOpt(d time.Duration) WatchOpt {
+	return func(w *Watch) {
+		w.resyncInterval = d
+	}
+}
+
+// StopGracePeriodOpt overrides how long stopAll waits for a watcher
+// stuck in its initial list to exit before abandoning it.
+func StopGracePeriodOpt(d time.Duration) WatchOpt {
+	return func(w *Watch) {
+		w.stopGracePeriod = d
+	}
+}
+
+// MetadataOnlyOpt registers GVKs that Watch should store as
+// metadata-only projections rather than full objects, to save cache
+// memory on kinds where Octant only needs names, labels, owner
+// references, and timestamps (list-heavy kinds like Events and
+// Endpoints, or anything with large specs/status). The full object can
+// still be fetched on demand straight from the cluster when a user
+// navigates to one.
+func MetadataOnlyOpt(gvks ...schema.GroupVersionKind) WatchOpt {
+	return func(w *Watch) {
+		for _, gvk := range gvks {
+			w.metadataOnlyGVKs[gvk] = true
+		}
+	}
+}
+
+// SelectorOpt scopes the watch for gvr to objects matching label and
+// field, passed through to the underlying list/watch calls as
+// metav1.ListOptions.LabelSelector/FieldSelector. A nil selector leaves
+// that axis unfiltered. This narrows what Watch stores in the cache in
+// the first place; CacheKey.LabelSelector/FieldSelector narrow further,
+// read-side, on top of whatever was watched.
+func SelectorOpt(gvr schema.GroupVersionResource, label labels.Selector, field fields.Selector) WatchOpt {
+	return func(w *Watch) {
+		w.labelSelectors[gvr] = label
+		w.fieldSelectors[gvr] = field
+	}
+}
+
+// watcherState tracks a single GVR's running shared informer so it can
+// be torn down independently of every other GVR.
+type watcherState struct {
+	informer k8scache.SharedIndexInformer
+	stopCh   chan struct{}
+	done     chan struct{}
+}
+
+// Watch keeps a Cache in sync with a cluster namespace. It
+// discovers watchable resources and, for each, runs a shared index
+// informer that lists the current state into the cache and then
+// delivers subsequent adds/updates/deletes as events, giving Octant
+// resync-on-reconnect and correct resourceVersion handling for free
+// instead of Watch having to re-implement them.
+type Watch struct {
+	namespace string
+	client    cluster.ClientInterface
+	cache     Cache
+	logger    log.Logger
+
+	resyncInterval  time.Duration
+	stopGracePeriod time.Duration
+
+	mu       sync.Mutex
+	watchers map[schema.GroupVersionResource]*watcherState
+
+	// pendingHandlers holds handlers registered via AddEventHandler for
+	// a GVR before its informer exists yet; they're attached as soon as
+	// startWatch creates it.
+	pendingHandlers map[schema.GroupVersionResource][]k8scache.ResourceEventHandlerFuncs
+
+	metadataOnlyGVKs map[schema.GroupVersionKind]bool
+
+	labelSelectors map[schema.GroupVersionResource]labels.Selector
+	fieldSelectors map[schema.GroupVersionResource]fields.Selector
+
+	reconcileCh chan struct{}
+	stopCh      chan struct{}
+}
+
+// NewWatch creates a Watch for namespace.
+func NewWatch(namespace string, client cluster.ClientInterface, cache Cache, logger log.Logger, opts ...WatchOpt) *Watch {
+	w := &Watch{
+		namespace:        namespace,
+		client:           client,
+		cache:            cache,
+		logger:           logger,
+		resyncInterval:   defaultResyncInterval,
+		stopGracePeriod:  defaultStopGracePeriod,
+		watchers:         make(map[schema.GroupVersionResource]*watcherState),
+		pendingHandlers:  make(map[schema.GroupVersionResource][]k8scache.ResourceEventHandlerFuncs),
+		metadataOnlyGVKs: make(map[schema.GroupVersionKind]bool),
+		labelSelectors:   make(map[schema.GroupVersionResource]labels.Selector),
+		fieldSelectors:   make(map[schema.GroupVersionResource]fields.Selector),
+		reconcileCh:      make(chan struct{}, 1),
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
+}
+
+// AddEventHandler registers handler to be called for every add, update,
+// and delete event observed for gvr, alongside the handler Watch always
+// registers to keep the cache in sync. If gvr isn't being watched
+// yet, handler is attached as soon as it is.
+func (w *Watch) AddEventHandler(gvr schema.GroupVersionResource, handler k8scache.ResourceEventHandlerFuncs) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if state, ok := w.watchers[gvr]; ok {
+		state.informer.AddEventHandler(handler)
+		return
+	}
+
+	w.pendingHandlers[gvr] = append(w.pendingHandlers[gvr], handler)
+}
+
+// Indexer returns gvr's informer indexer, for O(1) namespace/name
+// lookups against the objects Watch has observed, as an alternative to
+// MemoryCache.Retrieve's linear scan over every cached object. It
+// returns false if gvr isn't being watched yet.
+func (w *Watch) Indexer(gvr schema.GroupVersionResource) (k8scache.Indexer, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	state, ok := w.watchers[gvr]
+	if !ok {
+		return nil, false
+	}
+
+	return state.informer.GetIndexer(), true
+}
+
+// Start discovers watchable resources in the namespace and starts a
+// watcher for each. It also launches a reconciler that periodically
+// repeats discovery so watchers get started for GVRs that appear later
+// and stopped (and their objects purged from the cache) for ones that
+// disappear. Start returns a function that stops every watcher and
+// waits up to the stop grace period for them to exit; that function
+// completes even if a watcher is mid-list for a resource that was
+// removed from the cluster, since any watcher still blocked once the
+// grace period is over is abandoned rather than waited for.
+func (w *Watch) Start() (func(), error) {
+	w.stopCh = make(chan struct{})
+
+	if err := w.startMissingWatches(); err != nil {
+		return nil, err
+	}
+
+	reconcilerDone := make(chan struct{})
+	go func() {
+		defer close(reconcilerDone)
+
+		ticker := time.NewTicker(w.resyncInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := w.startMissingWatches(); err != nil {
+					w.logger.WithErr(err).Errorf("reconcile watchers for %s", w.namespace)
+				}
+			case <-w.reconcileCh:
+				if err := w.startMissingWatches(); err != nil {
+					w.logger.WithErr(err).Errorf("reconcile watchers for %s", w.namespace)
+				}
+			case <-w.stopCh:
+				return
+			}
+		}
+	}()
+
+	stopFn := func() {
+		close(w.stopCh)
+		<-reconcilerDone
+		w.stopAll()
+	}
+
+	return stopFn, nil
+}
+
+// triggerReconcile schedules an out-of-band call to startMissingWatches
+// the next time the reconciler loop runs, for callers that learn about a
+// discovery change out of band instead of waiting for the next tick.
+func (w *Watch) triggerReconcile() {
+	select {
+	case w.reconcileCh <- struct{}{}:
+	default:
+	}
+}
+
+// startMissingWatches discovers the GVRs the cluster currently supports,
+// starts a watcher for any that don't already have one running, and
+// stops and removes watchers for any GVR that no longer appears in
+// discovery (for instance a CRD that was deleted). Discovery and all
+// dynamic-client I/O happen without holding w.mu: each GVR is checked
+// against the running watchers under the lock, the lock is dropped
+// while startWatch builds an informer whose (potentially slow)
+// list-and-watch calls run on its own goroutine, and the lock is only
+// re-acquired to record the resulting watcherState. This means a
+// blocked or erroring list for one GVR can never prevent another GVR
+// from being watched, or prevent Start's stop function from completing.
+func (w *Watch) startMissingWatches() error {
+	discoveryClient, err := w.client.DiscoveryClient()
+	if err != nil {
+		return err
+	}
+
+	gvrs, err := watchableResources(discoveryClient)
+	if err != nil {
+		return err
+	}
+
+	dynamicClient, err := w.client.DynamicClient()
+	if err != nil {
+		return err
+	}
+
+	current := make(map[schema.GroupVersionResource]bool, len(gvrs))
+
+	for _, gvr := range gvrs {
+		current[gvr] = true
+
+		w.mu.Lock()
+		_, running := w.watchers[gvr]
+		w.mu.Unlock()
+
+		if running {
+			continue
+		}
+
+		state := w.startWatch(dynamicClient, gvr)
+
+		w.mu.Lock()
+		if _, running := w.watchers[gvr]; running {
+			w.mu.Unlock()
+			// Someone else already won the race to register gvr; stop
+			// the loser without waiting for it to exit; if it's stuck in
+			// its initial list, nothing reads from it again once
+			// abandoned, and it exits whenever that list eventually
+			// returns.
+			close(state.stopCh)
+			continue
+		}
+		// Drain handlers registered via AddEventHandler while startWatch
+		// was running: it already drained pendingHandlers once before
+		// gvr was visible in w.watchers, so anything added in between is
+		// still sitting here and would otherwise never be attached.
+		for _, handler := range w.pendingHandlers[gvr] {
+			state.informer.AddEventHandler(handler)
+		}
+		delete(w.pendingHandlers, gvr)
+		w.watchers[gvr] = state
+		w.mu.Unlock()
+	}
+
+	w.mu.Lock()
+	stale := make(map[schema.GroupVersionResource]*watcherState)
+	for gvr, state := range w.watchers {
+		if !current[gvr] {
+			stale[gvr] = state
+			delete(w.watchers, gvr)
+		}
+	}
+	w.mu.Unlock()
+
+	for gvr, state := range stale {
+		w.stopWatcher(gvr, state)
+	}
+
+	return nil
+}
+
+// stopWatcher closes state's stopCh without waiting for it to exit, and
+// purges every object its informer had cached: once a GVR's watcher is
+// gone, Watch will never see a delete event for those objects again, so
+// leaving them in the cache would mean serving stale, possibly
+// long-deleted objects forever.
+func (w *Watch) stopWatcher(gvr schema.GroupVersionResource, state *watcherState) {
+	close(state.stopCh)
+
+	for _, obj := range state.informer.GetIndexer().List() {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+
+		if err := w.cache.Delete(w.namespace, w.projectIfMetadataOnly(u)); err != nil {
+			w.logger.WithErr(err).Errorf("purge %s after its watcher stopped", gvr)
+		}
+	}
+}
+
+// startWatch builds a shared index informer for gvr - backed by the
+// dynamic client's List/Watch for this namespace - registers the
+// handler that keeps the cache in sync plus any handlers callers
+// already registered via AddEventHandler, and runs it until its stopCh
+// is closed.
+func (w *Watch) startWatch(dynamicClient dynamic.Interface, gvr schema.GroupVersionResource) *watcherState {
+	resClient := dynamicClient.Resource(gvr).Namespace(w.namespace)
+
+	label := w.labelSelectors[gvr]
+	field := w.fieldSelectors[gvr]
+
+	applySelectors := func(opts metav1.ListOptions) metav1.ListOptions {
+		if label != nil {
+			opts.LabelSelector = label.String()
+		}
+		if field != nil {
+			opts.FieldSelector = field.String()
+		}
+		return opts
+	}
+
+	lw := &k8scache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			return resClient.List(applySelectors(opts))
+		},
+		WatchFunc: func(opts metav1.ListOptions) (kubewatch.Interface, error) {
+			return resClient.Watch(applySelectors(opts))
+		},
+	}
+
+	informer := k8scache.NewSharedIndexInformer(lw, &unstructured.Unstructured{}, w.resyncInterval, k8scache.Indexers{})
+
+	informer.AddEventHandler(k8scache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { w.storeObject(gvr, obj) },
+		UpdateFunc: func(_, obj interface{}) { w.storeObject(gvr, obj) },
+		DeleteFunc: func(obj interface{}) { w.deleteObject(gvr, obj) },
+	})
+
+	w.mu.Lock()
+	for _, handler := range w.pendingHandlers[gvr] {
+		informer.AddEventHandler(handler)
+	}
+	delete(w.pendingHandlers, gvr)
+	w.mu.Unlock()
+
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		informer.Run(stopCh)
+	}()
+
+	return &watcherState{informer: informer, stopCh: stopCh, done: done}
+}
+
+// storeObject is the informer AddFunc/UpdateFunc for every GVR: it
+// keeps the cache in sync with what the informer has observed.
+func (w *Watch) storeObject(gvr schema.GroupVersionResource, obj interface{}) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+
+	if err := w.cache.Store(w.namespace, w.projectIfMetadataOnly(u)); err != nil {
+		w.logger.WithErr(err).Errorf("store %s", gvr)
+	}
+}
+
+// deleteObject is the informer DeleteFunc for every GVR. obj may arrive
+// as a k8scache.DeletedFinalStateUnknown if the delete was observed via a
+// relist rather than a watch event.
+func (w *Watch) deleteObject(gvr schema.GroupVersionResource, obj interface{}) {
+	if tombstone, ok := obj.(k8scache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+
+	if err := w.cache.Delete(w.namespace, w.projectIfMetadataOnly(u)); err != nil {
+		w.logger.WithErr(err).Errorf("delete %s", gvr)
+	}
+}
+
+// projectIfMetadataOnly returns u unchanged unless its GVK was registered
+// via MetadataOnlyOpt, in which case it returns a copy stripped down to
+// apiVersion, kind, and metadata only - the same fields
+// k8s.io/apimachinery/pkg/apis/meta/v1beta1.PartialObjectMetadata
+// carries. client-go's metadata/metadatainformer packages would get us
+// this projection straight from the API server's watch stream, but they
+// require a client-go newer than the one this namespace's dynamic client
+// is pinned to, so Watch does the equivalent projection itself after the
+// full object arrives.
+func (w *Watch) projectIfMetadataOnly(u *unstructured.Unstructured) *unstructured.Unstructured {
+	if !w.metadataOnlyGVKs[u.GroupVersionKind()] {
+		return u
+	}
+
+	projected := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": u.GetAPIVersion(),
+			"kind":       u.GetKind(),
+		},
+	}
+	if metadata, ok := u.Object["metadata"]; ok {
+		projected.Object["metadata"] = metadata
+	}
+
+	return projected
+}
+
+// stopAll cancels every running watcher and waits for them to exit. The
+// watcher map is copied and cleared under the lock, and the cancel/wait
+// happens afterwards so a watcher blocked in I/O can't hold up anyone
+// else trying to read or update the map.
+//
+// Closing a watcher's stopCh can't interrupt a List call already in
+// flight - client-go@v8's Reflector.ListAndWatch has no way to cancel
+// one - so a watcher whose GVR was deleted mid-list can block its
+// informer's Run forever. stopAll only waits up to stopGracePeriod for
+// every watcher to exit; past that it gives up and returns, leaving any
+// still-blocked watcher to exit on its own whenever its list call
+// eventually returns or errors.
+func (w *Watch) stopAll() {
+	w.mu.Lock()
+	watchers := w.watchers
+	w.watchers = make(map[schema.GroupVersionResource]*watcherState)
+	w.mu.Unlock()
+
+	for _, state := range watchers {
+		close(state.stopCh)
+	}
+
+	allDone := make(chan struct{})
+	go func() {
+		defer close(allDone)
+		for _, state := range watchers {
+			<-state.done
+		}
+	}()
+
+	select {
+	case <-allDone:
+	case <-time.After(w.stopGracePeriod):
+		w.logger.Warnf("stopping watch for %s: one or more watchers still blocked in their initial list after %s, abandoning them", w.namespace, w.stopGracePeriod)
+	}
+}
+
+// watchableResources returns the GVRs the cluster supports that can be
+// both listed and watched.
+func watchableResources(discoveryClient discovery.DiscoveryInterface) ([]schema.GroupVersionResource, error) {
+	lists, err := discoveryClient.ServerResources()
+	if err != nil {
+		return nil, err
+	}
+
+	var gvrs []schema.GroupVersionResource
+
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+
+		for _, resource := range list.APIResources {
+			if !hasVerbs(resource.Verbs, "list", "watch") {
+				continue
+			}
+
+			gvrs = append(gvrs, gv.WithResource(resource.Name))
+		}
+	}
+
+	return gvrs, nil
+}
+
+func hasVerbs(verbs metav1.Verbs, want ...string) bool {
+	have := make(map[string]bool, len(verbs))
+	for _, v := range verbs {
+		have[v] = true
+	}
+
+	for _, w := range want {
+		if !have[w] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
@@ -210,6 +211,267 @@ func TestWatch_Stop(t *testing.T) {
 	}
 }
 
+func TestWatch_Selector(t *testing.T) {
+	scheme := newScheme()
+
+	matching := newUnstructured("apps/v1", "Deployment", "default", "deploy-match")
+	matching.SetLabels(map[string]string{"app": "wanted"})
+
+	other := newUnstructured("apps/v1", "Deployment", "default", "deploy-other")
+	other.SetLabels(map[string]string{"app": "unwanted"})
+
+	objects := []runtime.Object{matching, other}
+
+	clusterClient, err := fake.NewClient(scheme, objects)
+	require.NoError(t, err)
+
+	discoveryClient := clusterClient.FakeDiscovery
+	discoveryClient.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{
+				{
+					Name:         "deployments",
+					SingularName: "deployment",
+					Group:        "apps",
+					Version:      "v1",
+					Kind:         "Deployment",
+					Namespaced:   true,
+					Verbs:        metav1.Verbs{"list", "watch"},
+					Categories:   []string{"all"},
+				},
+			},
+		},
+	}
+
+	notifyCh := make(chan CacheNotification)
+	notifyDone := make(chan struct{})
+
+	cache := NewMemoryCache(CacheNotificationOpt(notifyCh, notifyDone))
+
+	gvr := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	selector := labels.SelectorFromSet(labels.Set{"app": "wanted"})
+
+	watch := NewWatch("default", clusterClient, cache, log.TestLogger(t), SelectorOpt(gvr, selector, nil))
+
+	stopFn, err := watch.Start()
+	require.NoError(t, err)
+
+	defer func() {
+		close(notifyDone) // Unblock any pending cache notifications so that stopFn can complete
+		stopFn()
+	}()
+
+	// wait for the matching object to be stored
+	select {
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for matching object to notify")
+	case n := <-notifyCh:
+		require.Equal(t, "deploy-match", n.Key.Name)
+	}
+
+	found, err := cache.Retrieve(CacheKey{Namespace: "default"})
+	require.NoError(t, err)
+
+	require.Len(t, found, 1)
+	require.Equal(t, "deploy-match", found[0].GetName())
+}
+
+func TestWatch_StopWhileListBlocked(t *testing.T) {
+	scheme := newScheme()
+
+	objects := []runtime.Object{
+		newUnstructured("apps/v1", "Deployment", "default", "deploy3"),
+	}
+
+	clusterClient, err := fake.NewClient(scheme, objects)
+	require.NoError(t, err)
+
+	discoveryClient := clusterClient.FakeDiscovery
+	discoveryClient.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{
+				{
+					Name:         "deployments",
+					SingularName: "deployment",
+					Group:        "apps",
+					Version:      "v1",
+					Kind:         "Deployment",
+					Namespaced:   true,
+					Verbs:        metav1.Verbs{"list", "watch"},
+					Categories:   []string{"all"},
+				},
+			},
+		},
+	}
+
+	gvr := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+	// unblock is never closed: this GVR's list never returns, as if its
+	// CRD were deleted out from under a watcher already mid-list.
+	unblock := make(chan struct{})
+	clusterClient.BlockList(gvr, unblock)
+
+	cache := NewMemoryCache()
+
+	watch := NewWatch("default", clusterClient, cache, log.TestLogger(t), StopGracePeriodOpt(100*time.Millisecond))
+
+	stopFn, err := watch.Start()
+	require.NoError(t, err)
+
+	stopDone := make(chan interface{})
+	go func() {
+		stopFn()
+		close(stopDone)
+	}()
+
+	select {
+	case <-time.After(2 * time.Second):
+		t.Fatal("stopFn did not complete while a list call was blocked")
+	case <-stopDone:
+		// Success: stopFn gave up on the blocked watcher instead of
+		// waiting for it forever.
+	}
+}
+
+func TestWatch_MetadataOnly(t *testing.T) {
+	scheme := newScheme()
+
+	seed := newUnstructured("apps/v1", "Deployment", "default", "deploy3")
+	seed.SetLabels(map[string]string{"app": "web"})
+	seed.Object["spec"] = map[string]interface{}{"replicas": int64(3)}
+	seed.Object["status"] = map[string]interface{}{"readyReplicas": int64(1)}
+
+	objects := []runtime.Object{seed}
+
+	clusterClient, err := fake.NewClient(scheme, objects)
+	require.NoError(t, err)
+
+	discoveryClient := clusterClient.FakeDiscovery
+	discoveryClient.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{
+				{
+					Name:         "deployments",
+					SingularName: "deployment",
+					Group:        "apps",
+					Version:      "v1",
+					Kind:         "Deployment",
+					Namespaced:   true,
+					Verbs:        metav1.Verbs{"list", "watch"},
+					Categories:   []string{"all"},
+				},
+			},
+		},
+	}
+
+	notifyCh := make(chan CacheNotification)
+	notifyDone := make(chan struct{})
+
+	cache := NewMemoryCache(CacheNotificationOpt(notifyCh, notifyDone))
+
+	gvk := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+
+	watch := NewWatch("default", clusterClient, cache, log.TestLogger(t), MetadataOnlyOpt(gvk))
+
+	stopFn, err := watch.Start()
+	require.NoError(t, err)
+
+	defer func() {
+		close(notifyDone)
+		stopFn()
+	}()
+
+	select {
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for initial object to notify")
+	case <-notifyCh:
+	}
+
+	found, err := cache.Retrieve(CacheKey{Namespace: "default"})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+
+	cached := found[0]
+	require.Equal(t, "apps/v1", cached.GetAPIVersion())
+	require.Equal(t, "Deployment", cached.GetKind())
+	require.Equal(t, "deploy3", cached.GetName())
+	require.Equal(t, map[string]string{"app": "web"}, cached.GetLabels())
+
+	_, hasSpec := cached.Object["spec"]
+	require.False(t, hasSpec, "metadata-only projection should strip spec")
+	_, hasStatus := cached.Object["status"]
+	require.False(t, hasStatus, "metadata-only projection should strip status")
+}
+
+func TestWatch_RemovedGVRStopsAndPurgesCache(t *testing.T) {
+	scheme := newScheme()
+
+	objects := []runtime.Object{
+		newUnstructured("apps/v1", "Deployment", "default", "deploy1"),
+	}
+
+	clusterClient, err := fake.NewClient(scheme, objects)
+	require.NoError(t, err)
+
+	discoveryClient := clusterClient.FakeDiscovery
+	discoveryClient.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{
+				{
+					Name:         "deployments",
+					SingularName: "deployment",
+					Group:        "apps",
+					Version:      "v1",
+					Kind:         "Deployment",
+					Namespaced:   true,
+					Verbs:        metav1.Verbs{"list", "watch"},
+					Categories:   []string{"all"},
+				},
+			},
+		},
+	}
+
+	cache := NewMemoryCache()
+
+	watch := NewWatch("default", clusterClient, cache, log.TestLogger(t))
+	defer watch.stopAll()
+
+	// First reconcile tick: the deployments GVR is in discovery, so it
+	// gets a watcher and its object lands in the cache.
+	require.NoError(t, watch.startMissingWatches())
+	waitForCacheLen(t, cache, 1, "object never appeared in the cache")
+
+	// The deployments CRD is deleted: it no longer appears in discovery.
+	// There's no concurrent reconciler goroutine running here, so it's
+	// safe to mutate discoveryClient.Resources directly between the two
+	// startMissingWatches calls that stand in for two reconcile ticks.
+	discoveryClient.Resources = nil
+
+	// Second reconcile tick: startMissingWatches should notice
+	// deployments is gone, stop its watcher, and purge its objects.
+	require.NoError(t, watch.startMissingWatches())
+	waitForCacheLen(t, cache, 0, "object was never purged from the cache after its GVR disappeared")
+}
+
+func waitForCacheLen(t *testing.T, cache *MemoryCache, want int, msg string) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		found, err := cache.Retrieve(CacheKey{Namespace: "default"})
+		require.NoError(t, err)
+		if len(found) == want {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal(msg)
+}
+
 func newUnstructured(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
 	return &unstructured.Unstructured{
 		Object: map[string]interface{}{